@@ -1,11 +1,16 @@
 package resolvelock
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
@@ -35,6 +40,65 @@ type Config struct {
 	LockPerRegion int
 	Worker        int
 	LocalMode     bool
+
+	// Generators is a weighted mix of the named LockGenerator implementations (see
+	// lockGenerators) that a round draws from to write each batch of locks, e.g.
+	// {"optimistic": 3, "pessimistic": 1}. A nil/empty map defaults to 100% optimistic,
+	// preserving the original behavior.
+	Generators map[string]int
+
+	// EnableLogBackup simulates a log-backup advancer whose checkpoint-ts advances
+	// independently of the GC safe point. When enabled, the safe point GC runs with is
+	// capped at min(gcSafePoint, logBackupCheckpointTs), reproducing the real constraint
+	// log backup places on GC.
+	EnableLogBackup bool
+	// LogBackupAdvanceInterval controls how often the advancer recomputes its checkpoint.
+	LogBackupAdvanceInterval time.Duration
+	// LogBackupStallRegion, when non-zero, is the 1-based ordinal (in PD's region scan
+	// order) of a region whose checkpoint never advances, exercising the "slow region
+	// blocks the global checkpoint" path. 0 disables stalling.
+	LogBackupStallRegion int
+
+	// TLSConfig configures TLS/mTLS for connections to PD, TiKV, and TiDB's status port. A
+	// zero value means the case connects in plaintext, as before.
+	TLSConfig TLSConfig
+}
+
+// TLSConfig holds the client certificate, key, and CA used to talk to a TLS-enabled cluster.
+type TLSConfig struct {
+	CAPath     string
+	CertPath   string
+	KeyPath    string
+	ServerName string
+}
+
+// Enabled reports whether any TLS material was configured.
+func (t TLSConfig) Enabled() bool {
+	return t.CAPath != "" || t.CertPath != "" || t.KeyPath != ""
+}
+
+// tlsConfig builds a *tls.Config from t, or returns nil if TLS isn't configured.
+func (t TLSConfig) tlsConfig() (*tls.Config, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertPath, t.KeyPath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	caData, err := ioutil.ReadFile(t.CAPath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, errors.New("failed to append ca certs from " + t.CAPath)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   t.ServerName,
+	}, nil
 }
 
 // Normalize normalizes unexpected config
@@ -48,6 +112,12 @@ func (c *Config) Normalize() *Config {
 	if c.Worker == 0 {
 		c.Worker = 10
 	}
+	if c.LogBackupAdvanceInterval == 0 {
+		c.LogBackupAdvanceInterval = time.Second
+	}
+	if len(c.Generators) == 0 {
+		c.Generators = map[string]int{"optimistic": 1}
+	}
 	return c
 }
 
@@ -58,9 +128,18 @@ type CaseCreator struct {
 
 // Create creates the resolveLockClient from the CaseCreator
 func (l CaseCreator) Create(node cluster.ClientNode) core.Client {
+	cfg := l.Cfg.Normalize()
+	generators, err := newWeightedGenerators(cfg.Generators)
+	if err != nil {
+		// Config is validated ahead of time by the caller; a bad Generators map here is a
+		// programming error, not a runtime condition the case should try to recover from.
+		log.Fatalf("invalid lock generators: %v", err)
+	}
 	return &resolveLockClient{
-		Config: l.Cfg.Normalize(),
-		dbName: "resolve_lock",
+		Config:     cfg,
+		dbName:     "resolve_lock",
+		generators: generators,
+		oracle:     newLockOracle(),
 	}
 }
 
@@ -75,7 +154,24 @@ type resolveLockClient struct {
 	safeLockTs uint64
 	mockLockTs uint64
 
+	// pessimisticPrimaries tracks, for every transaction that was written as a pessimistic
+	// lock, which key was its primary. It's consulted after GC to make sure the primary
+	// pessimistic lock was actually rolled back and not merely left behind.
+	pessimisticPrimaries sync.Map // startTs(uint64) -> primary([]byte)
+
+	// generators picks which LockGenerator writes the next batch of locks, per Config.Generators.
+	generators *weightedGenerators
+	// oracle records what each generator wrote, so CheckData can tell an expected leftover
+	// apart from a genuine bug.
+	oracle *lockOracle
+
+	// logBackupCheckpointTs is the global log-backup checkpoint published by
+	// runLogBackupAdvancer; it's consulted to cap the safe point GC runs with. 0 means no
+	// checkpoint has been published yet (treated as "no constraint").
+	logBackupCheckpointTs uint64
+
 	dbStatusAddr string
+	httpClient   *http.Client
 	db           *sql.DB
 	pd           pd.Client
 	kv           tikv.Storage
@@ -108,7 +204,7 @@ func (c *resolveLockClient) CreateTable(ctx context.Context, i int) (int64, erro
 	}
 
 	url := fmt.Sprintf("%s/schema/%s/%s", c.dbStatusAddr, c.dbName, table)
-	resp, err := httputil.NewHTTPClient(http.DefaultClient).Get(url)
+	resp, err := httputil.NewHTTPClient(c.httpClient).Get(url)
 	if err != nil {
 		return 0, errors.Trace(err)
 	}
@@ -131,6 +227,17 @@ func (c *resolveLockClient) SetUp(ctx context.Context, nodes []cluster.Node, cli
 		log.Infof("init end")
 	}()
 
+	tlsConfig, err := c.TLSConfig.tlsConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	scheme := "http"
+	c.httpClient = http.DefaultClient
+	if tlsConfig != nil {
+		scheme = "https"
+		c.httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	}
+
 	// PD
 	var pdAddr string
 	if c.LocalMode {
@@ -139,7 +246,11 @@ func (c *resolveLockClient) SetUp(ctx context.Context, nodes []cluster.Node, cli
 		pdNode := nodes[0]
 		pdAddr = fmt.Sprintf("%s-pd.%s.svc:2379", pdNode.ClusterName, pdNode.Namespace)
 	}
-	pdClient, err := pd.NewClient([]string{pdAddr}, pd.SecurityOption{})
+	pdClient, err := pd.NewClient([]string{pdAddr}, pd.SecurityOption{
+		CAPath:   c.TLSConfig.CAPath,
+		CertPath: c.TLSConfig.CertPath,
+		KeyPath:  c.TLSConfig.KeyPath,
+	})
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -147,7 +258,11 @@ func (c *resolveLockClient) SetUp(ctx context.Context, nodes []cluster.Node, cli
 
 	// TiKV
 	driver := tikv.Driver{}
-	store, err := driver.Open(fmt.Sprintf("tikv://%s?disableGC=true", pdAddr))
+	tikvDSN := fmt.Sprintf("tikv://%s?disableGC=true", pdAddr)
+	if c.TLSConfig.Enabled() {
+		tikvDSN += fmt.Sprintf("&ca=%s&cert=%s&key=%s", c.TLSConfig.CAPath, c.TLSConfig.CertPath, c.TLSConfig.KeyPath)
+	}
+	store, err := driver.Open(tikvDSN)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -157,10 +272,10 @@ func (c *resolveLockClient) SetUp(ctx context.Context, nodes []cluster.Node, cli
 	var dbNode cluster.ClientNode
 	if c.LocalMode {
 		dbNode = cluster.ClientNode{IP: "127.0.0.1", Port: 4000}
-		c.dbStatusAddr = fmt.Sprintf("http://%s:10080", dbNode.IP)
+		c.dbStatusAddr = fmt.Sprintf("%s://%s:10080", scheme, dbNode.IP)
 	} else {
 		dbNode = clientNodes[idx]
-		c.dbStatusAddr = fmt.Sprintf("http://%s-tidb.%s.svc:10080", dbNode.ClusterName, dbNode.Namespace)
+		c.dbStatusAddr = fmt.Sprintf("%s://%s-tidb.%s.svc:10080", scheme, dbNode.ClusterName, dbNode.Namespace)
 	}
 
 	err = c.openDB(ctx, dbNode.IP, dbNode.Port)
@@ -196,6 +311,10 @@ func (c *resolveLockClient) Start(ctx context.Context, cfg interface{}, clientNo
 		log.Info("test end")
 	}()
 
+	if c.EnableLogBackup {
+		go c.runLogBackupAdvancer(ctx)
+	}
+
 	lastGreenGC := -1
 	for loopNum := 0; ; loopNum++ {
 		select {
@@ -234,6 +353,16 @@ func (c *resolveLockClient) Start(ctx context.Context, cfg interface{}, clientNo
 		} else if ts == 0 {
 			return nil
 		}
+		// rawSafePoint is the safe point before any log-backup cap is applied. It's the
+		// upper bound verifyLogBackupBarrier checks against below: if GC disregarded the
+		// cap and ran at rawSafePoint instead of the capped c.safePoint, a committed
+		// record in (logBackupTs, rawSafePoint] would have been removed.
+		rawSafePoint := c.safePoint
+		logBackupTs := atomic.LoadUint64(&c.logBackupCheckpointTs)
+		if logBackupTs != 0 && logBackupTs < c.safePoint {
+			log.Infof("[round-%d] log backup checkpoint(%v) constrains safePoint(%v)", loopNum, logBackupTs, c.safePoint)
+			c.safePoint = logBackupTs
+		}
 		log.Infof("[round-%d] start to GC at safePoint(%v)", loopNum, c.safePoint)
 		// Invoke GC with the safe point
 		var greenGCUsed bool
@@ -254,8 +383,18 @@ func (c *resolveLockClient) Start(ctx context.Context, cfg interface{}, clientNo
 		}
 		log.Infof("[round-%d] GC done at safePoint(%v)", loopNum, c.safePoint)
 
+		// Cancel all goroutines that are generating locks asynchronously before inspecting
+		// the keyspace: verifyGreenGC's physical/logical scans and CheckData both need a
+		// quiescent state, otherwise a lock written between the two scans can appear in
+		// only one of them and produce a spurious mismatch.
+		cancel()
+		wg.Wait()
+
 		if greenGCUsed {
 			lastGreenGC = loopNum
+			if err := c.verifyGreenGC(ctx, loopNum); err != nil {
+				return errors.Trace(err)
+			}
 		} else if c.EnableGreenGC {
 			log.Warnf("[round-%d] failed to resolve lock physically at safe point %v", loopNum, c.safePoint)
 		}
@@ -264,9 +403,6 @@ func (c *resolveLockClient) Start(ctx context.Context, cfg interface{}, clientNo
 		}
 
 		log.Infof("[round-%d] start to check data at safePoint(%v)", loopNum, c.safePoint)
-		// Cancel all goroutines that are generating locks asynchronously.
-		cancel()
-		wg.Wait()
 		// Check there is no lock between safeLockTs and safePoint
 		unsafeLocks, err := c.CheckData(ctx)
 		if len(unsafeLocks) != 0 {
@@ -277,13 +413,20 @@ func (c *resolveLockClient) Start(ctx context.Context, cfg interface{}, clientNo
 			return errors.Trace(err)
 		}
 		log.Infof("[round-%d] check data done at safePoint(%v)", loopNum, c.safePoint)
+
+		if c.EnableLogBackup {
+			if err := c.verifyLogBackupBarrier(ctx, loopNum, logBackupTs, rawSafePoint); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
 		c.reset(ctx)
 	}
 }
 
 func (c *resolveLockClient) resolveLocks(ctx context.Context) (bool, error) {
 	url := fmt.Sprintf("%s/test/gc/resolvelock?safepoint=%v&physical=%v", c.dbStatusAddr, c.safePoint, c.EnableGreenGC)
-	resp, err := httputil.NewHTTPClient(http.DefaultClient).Get(url)
+	resp, err := httputil.NewHTTPClient(c.httpClient).Get(url)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -313,6 +456,59 @@ func (c *resolveLockClient) asyncGenerateLocksDuringGC(ctx context.Context, safe
 	return cancel, &wg
 }
 
+// runLogBackupAdvancer periodically scans all regions and mocks each one reporting its own
+// log-backup checkpoint-ts, then publishes the minimum across regions as the global
+// checkpoint. It models the real log-backup advancer that runs inside PD/TiKV: GC must never
+// advance its safe point past this checkpoint, or it would delete data log backup hasn't
+// archived yet. It runs until ctx is canceled.
+func (c *resolveLockClient) runLogBackupAdvancer(ctx context.Context) {
+	regionCheckpoints := make(map[uint64]uint64)
+
+	ticker := time.NewTicker(c.LogBackupAdvanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		regions, err := c.pd.ScanRegions(ctx, []byte{}, nil, 0)
+		if err != nil {
+			log.Warnf("log backup advancer failed to scan regions: %v", err)
+			continue
+		}
+		if len(regions) == 0 {
+			continue
+		}
+
+		now, err := c.getTs(ctx)
+		if err != nil || now == 0 {
+			continue
+		}
+
+		var global uint64 = now
+		for i, region := range regions {
+			regionID := region.Meta.GetId()
+			// Mock a "get-region-checkpoint" RPC: in a real cluster this would ask the
+			// region's leader for the ts it has durably shipped to log backup storage.
+			// Here the region just reports "now" unless it's the one being stalled.
+			checkpoint := now
+			if c.LogBackupStallRegion != 0 && i+1 == c.LogBackupStallRegion {
+				if prev, ok := regionCheckpoints[regionID]; ok {
+					checkpoint = prev
+				}
+			}
+			regionCheckpoints[regionID] = checkpoint
+			if checkpoint < global {
+				global = checkpoint
+			}
+		}
+
+		atomic.StoreUint64(&c.logBackupCheckpointTs, global)
+	}
+}
+
 func (c *resolveLockClient) generateLocks(ctx context.Context, interval time.Duration) (int, error) {
 	type task struct {
 		tableID  int64
@@ -373,7 +569,7 @@ func (c *resolveLockClient) lock(ctx context.Context, tableID int64, handleID in
 	for i := 0; i < limit; i++ {
 		keys = append(keys, tablecodec.EncodeRowKeyWithHandle(tableID, handleID+int64(i)))
 		if len(keys) >= txnSize || i == limit-1 {
-			cnt, err := c.lockBatch(ctx, keys)
+			cnt, err := c.lockBatch(ctx, keys, c.generators.pick())
 			if err != nil {
 				return 0, errors.Trace(err)
 			}
@@ -384,17 +580,33 @@ func (c *resolveLockClient) lock(ctx context.Context, tableID int64, handleID in
 	return locked, nil
 }
 
-func (c *resolveLockClient) lockBatch(ctx context.Context, keys [][]byte) (int, error) {
-	const maxBatchSize = 1024 * 1024 // 1MiB
-
+// lockBatch writes a batch of locks belonging to the same small transaction, using gen to
+// decide the prewrite shape (optimistic, pessimistic, large-txn, async-commit, or 1PC).
+func (c *resolveLockClient) lockBatch(ctx context.Context, keys [][]byte, gen LockGenerator) (int, error) {
 	if len(keys) == 0 {
 		return 0, nil
 	}
+	lockedKeys, err := gen.LockBatch(ctx, c, keys)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return lockedKeys, nil
+}
+
+// lockRound bundles the region lookup and start-ts allocation shared by every LockGenerator,
+// retrying automatically on region errors. fn builds and sends the request for the resolved
+// region and primary (keys[0]) and reports whether the round should retry with a fresh
+// region lookup.
+func (c *resolveLockClient) lockRound(
+	ctx context.Context,
+	keys [][]byte,
+	maxBatchSize int,
+	fn func(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, primary []byte, startTs uint64) (lockedKeys int, retry bool, err error),
+) (int, error) {
+	primary := keys[0]
 	// TiKV client doesn't expose Prewrite interface directly. We need to manually locate the region and send the
 	// Prewrite requests.
 	for {
-		primary := keys[0]
-
 		bo := tikv.NewBackoffer(ctx, 60000)
 		loc, err := c.kv.GetRegionCache().LocateKey(bo, primary)
 		if err != nil {
@@ -411,67 +623,655 @@ func (c *resolveLockClient) lockBatch(ctx context.Context, keys [][]byte) (int,
 
 		// Write locks in the same region. It doesn't handle region errors or write conflict properly for simplicity.
 		batchSize := 0
-		var mutations []*kvrpcpb.Mutation
+		var keysInRegion [][]byte
 		for _, key := range keys {
 			if !loc.Contains(key) {
 				break
 			}
-			value := []byte{'v'}
-			mutations = append(mutations, &kvrpcpb.Mutation{
-				Op:    kvrpcpb.Op_Put,
-				Key:   key,
-				Value: value,
-			})
+			keysInRegion = append(keysInRegion, key)
 
-			batchSize += len(key) + len(value)
+			batchSize += len(key) + 1
 			if batchSize >= maxBatchSize {
 				break
 			}
 		}
-
-		lockedKeys := len(mutations)
-		if lockedKeys == 0 {
+		if len(keysInRegion) == 0 {
 			return 0, nil
 		}
 
-		req := tikvrpc.NewRequest(
-			tikvrpc.CmdPrewrite,
-			&kvrpcpb.PrewriteRequest{
-				Mutations:    mutations,
-				PrimaryLock:  primary,
-				StartVersion: startTs,
-				LockTtl:      30000,
-			},
-		)
+		lockedKeys, retry, err := fn(bo, loc, keysInRegion, primary, startTs)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if retry {
+			continue
+		}
+		return lockedKeys, nil
+	}
+}
+
+// sendPrewrite sends req for keysInRegion and returns how many keys were actually locked
+// together with the raw response (so callers can inspect fields like OnePcCommitTs), or
+// (0, nil, true, nil) to ask the caller to retry after a region error.
+func (c *resolveLockClient) sendPrewrite(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, req *tikvrpc.Request) (int, *kvrpcpb.PrewriteResponse, bool, error) {
+	lockedKeys := len(keysInRegion)
+
+	resp, err := c.kv.SendReq(bo, req, loc.Region, time.Second*60)
+	if err != nil {
+		return 0, nil, false, errors.Annotatef(err, "send request failed. region: %+v [%+q, %+q), keys: %+q", loc.Region, loc.StartKey, loc.EndKey, keysInRegion)
+	}
+	regionErr, err := resp.GetRegionError()
+	if err != nil {
+		return 0, nil, false, errors.Trace(err)
+	}
+	if regionErr != nil {
+		if err := bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String())); err != nil {
+			return 0, nil, false, errors.Trace(err)
+		}
+		return 0, nil, true, nil
+	}
+	if resp.Resp == nil {
+		return 0, nil, false, errors.Errorf("response body missing")
+	}
+	prewriteResp := resp.Resp.(*kvrpcpb.PrewriteResponse)
+	if keyErrors := prewriteResp.GetErrors(); len(keyErrors) != 0 {
+		log.Warnf("failed to write locks: %v", keyErrors)
+		lockedKeys = 0
+	}
+	return lockedKeys, prewriteResp, false, nil
+}
+
+// maxCommitTsWindow bounds how far past "now" the server may pick a commit ts for an
+// async-commit or 1PC prewrite, mirroring the statement-timeout window a real client
+// computes MaxCommitTs from.
+const maxCommitTsWindow = 10 * time.Second
+
+// commitTsBounds returns the MinCommitTs/MaxCommitTs pair a real async-commit or 1PC
+// prewrite would send: MinCommitTs must exceed startTs, and MaxCommitTs bounds the server's
+// choice to a short window from now. Without these the server can't satisfy the async-commit
+// or 1PC linearizability checks and silently falls back to an ordinary 2PC prewrite.
+func (c *resolveLockClient) commitTsBounds(ctx context.Context, startTs uint64) (minCommitTs, maxCommitTs uint64, err error) {
+	now, err := c.getTs(ctx)
+	if err != nil || now == 0 {
+		return 0, 0, errors.Trace(err)
+	}
+	maxCommitTs = oracle.ComposeTS(oracle.ExtractPhysical(now)+int64(maxCommitTsWindow/time.Millisecond), 0)
+	return startTs + 1, maxCommitTs, nil
+}
+
+// LockGenerator produces one class of lock-bearing transaction. Implementations differ in
+// how they prewrite a batch of keys belonging to the same small transaction (primary ==
+// keys[0]): plain optimistic, pessimistic, large transactions, async commit, or 1PC.
+type LockGenerator interface {
+	// Name identifies the generator for weighted selection, logging, and the oracle.
+	Name() string
+	// LockBatch writes keys as a single small transaction and returns how many keys were
+	// successfully locked.
+	LockBatch(ctx context.Context, c *resolveLockClient, keys [][]byte) (int, error)
+}
+
+// lockGenerators is the registry of LockGenerator implementations selectable via
+// Config.Generators.
+var lockGenerators = map[string]LockGenerator{
+	"optimistic":   optimisticGenerator{},
+	"pessimistic":  pessimisticGenerator{},
+	"large-txn":    largeTxnGenerator{},
+	"async-commit": asyncCommitGenerator{},
+	"1pc":          onePCGenerator{},
+}
+
+func putMutations(keys [][]byte) []*kvrpcpb.Mutation {
+	mutations := make([]*kvrpcpb.Mutation, 0, len(keys))
+	for _, key := range keys {
+		mutations = append(mutations, &kvrpcpb.Mutation{Op: kvrpcpb.Op_Put, Key: key, Value: []byte{'v'}})
+	}
+	return mutations
+}
+
+// optimisticGenerator is the original, and still the default, lock-writing path: a plain
+// optimistic prewrite with no secondaries or 1PC/async-commit flags.
+type optimisticGenerator struct{}
+
+func (optimisticGenerator) Name() string { return "optimistic" }
+
+func (g optimisticGenerator) LockBatch(ctx context.Context, c *resolveLockClient, keys [][]byte) (int, error) {
+	const maxBatchSize = 1024 * 1024 // 1MiB
+	return c.lockRound(ctx, keys, maxBatchSize, func(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, primary []byte, startTs uint64) (int, bool, error) {
+		req := tikvrpc.NewRequest(tikvrpc.CmdPrewrite, &kvrpcpb.PrewriteRequest{
+			Mutations:    putMutations(keysInRegion),
+			PrimaryLock:  primary,
+			StartVersion: startTs,
+			LockTtl:      30000,
+		})
+		lockedKeys, _, retry, err := c.sendPrewrite(bo, loc, keysInRegion, req)
+		if err == nil && !retry && lockedKeys != 0 {
+			c.oracle.record(startTs, oracleEntry{generator: g.Name(), primary: primary})
+		}
+		return lockedKeys, retry, err
+	})
+}
+
+// pessimisticGenerator first acquires pessimistic locks on the keys and then prewrites them
+// with IsPessimisticLock set, matching the real pessimistic-transaction protocol.
+type pessimisticGenerator struct{}
+
+func (pessimisticGenerator) Name() string { return "pessimistic" }
+
+func (g pessimisticGenerator) LockBatch(ctx context.Context, c *resolveLockClient, keys [][]byte) (int, error) {
+	const maxBatchSize = 1024 * 1024 // 1MiB
+	return c.lockRound(ctx, keys, maxBatchSize, func(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, primary []byte, startTs uint64) (int, bool, error) {
+		forUpdateTs, retry, err := c.acquirePessimisticLocks(ctx, bo, loc, keysInRegion, primary, startTs)
+		if err != nil || retry {
+			return 0, retry, err
+		}
+		c.pessimisticPrimaries.Store(startTs, primary)
+
+		mutations := putMutations(keysInRegion)
+		isPessimisticLock := make([]bool, len(mutations))
+		for i := range isPessimisticLock {
+			isPessimisticLock[i] = true
+		}
+		req := tikvrpc.NewRequest(tikvrpc.CmdPrewrite, &kvrpcpb.PrewriteRequest{
+			Mutations:         mutations,
+			PrimaryLock:       primary,
+			StartVersion:      startTs,
+			LockTtl:           30000,
+			IsPessimisticLock: isPessimisticLock,
+			ForUpdateTs:       forUpdateTs,
+		})
+		lockedKeys, _, retry, err := c.sendPrewrite(bo, loc, keysInRegion, req)
+		if err == nil && !retry && lockedKeys != 0 {
+			c.oracle.record(startTs, oracleEntry{generator: g.Name(), primary: primary})
+		}
+		return lockedKeys, retry, err
+	})
+}
+
+// largeTxnGenerator tags its prewrite with a TxnSize just past the 256MB large-transaction
+// threshold, exercising the code path TiKV uses to skip collecting rollback info for huge
+// transactions (this harness doesn't actually write 256MB of mutations per round; it only
+// needs the server to believe the transaction is that large).
+type largeTxnGenerator struct{}
+
+func (largeTxnGenerator) Name() string { return "large-txn" }
+
+const largeTxnSize = 256*1024*1024 + 1
+
+func (g largeTxnGenerator) LockBatch(ctx context.Context, c *resolveLockClient, keys [][]byte) (int, error) {
+	const maxBatchSize = 1024 * 1024 // 1MiB
+	return c.lockRound(ctx, keys, maxBatchSize, func(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, primary []byte, startTs uint64) (int, bool, error) {
+		req := tikvrpc.NewRequest(tikvrpc.CmdPrewrite, &kvrpcpb.PrewriteRequest{
+			Mutations:    putMutations(keysInRegion),
+			PrimaryLock:  primary,
+			StartVersion: startTs,
+			LockTtl:      30000,
+			TxnSize:      largeTxnSize,
+		})
+		lockedKeys, _, retry, err := c.sendPrewrite(bo, loc, keysInRegion, req)
+		if err == nil && !retry && lockedKeys != 0 {
+			c.oracle.record(startTs, oracleEntry{generator: g.Name(), primary: primary})
+		}
+		return lockedKeys, retry, err
+	})
+}
+
+// asyncCommitGenerator prewrites with UseAsyncCommit set and the non-primary keys of the
+// batch recorded as secondaries on the primary's mutation, matching the async-commit
+// protocol. MinCommitTs/MaxCommitTs are populated via commitTsBounds; without them the
+// server can't satisfy the async-commit linearizability check and silently falls back to
+// an ordinary prewrite.
+type asyncCommitGenerator struct{}
+
+func (asyncCommitGenerator) Name() string { return "async-commit" }
+
+func (g asyncCommitGenerator) LockBatch(ctx context.Context, c *resolveLockClient, keys [][]byte) (int, error) {
+	const maxBatchSize = 1024 * 1024 // 1MiB
+	return c.lockRound(ctx, keys, maxBatchSize, func(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, primary []byte, startTs uint64) (int, bool, error) {
+		minCommitTs, maxCommitTs, err := c.commitTsBounds(ctx, startTs)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		} else if minCommitTs == 0 {
+			return 0, false, nil
+		}
+		secondaries := make([][]byte, 0, len(keysInRegion)-1)
+		for _, key := range keysInRegion {
+			if !bytes.Equal(key, primary) {
+				secondaries = append(secondaries, key)
+			}
+		}
+		req := tikvrpc.NewRequest(tikvrpc.CmdPrewrite, &kvrpcpb.PrewriteRequest{
+			Mutations:      putMutations(keysInRegion),
+			PrimaryLock:    primary,
+			StartVersion:   startTs,
+			LockTtl:        30000,
+			UseAsyncCommit: true,
+			Secondaries:    secondaries,
+			MinCommitTs:    minCommitTs,
+			MaxCommitTs:    maxCommitTs,
+		})
+		lockedKeys, _, retry, err := c.sendPrewrite(bo, loc, keysInRegion, req)
+		if err == nil && !retry && lockedKeys != 0 {
+			c.oracle.record(startTs, oracleEntry{generator: g.Name(), primary: primary})
+		}
+		return lockedKeys, retry, err
+	})
+}
+
+// onePCGenerator prewrites with TryOnePc set. MinCommitTs/MaxCommitTs are populated via
+// commitTsBounds for the same reason as asyncCommitGenerator. A 1PC prewrite that actually
+// commits in one phase (PrewriteResponse.OnePcCommitTs != 0) must never leave a durable lock
+// behind; one that falls back to ordinary 2PC (OnePcCommitTs == 0, e.g. because the region
+// couldn't satisfy 1PC) behaves exactly like optimisticGenerator and is recorded as such.
+type onePCGenerator struct{}
+
+func (onePCGenerator) Name() string { return "1pc" }
+
+func (g onePCGenerator) LockBatch(ctx context.Context, c *resolveLockClient, keys [][]byte) (int, error) {
+	const maxBatchSize = 1024 * 1024 // 1MiB
+	return c.lockRound(ctx, keys, maxBatchSize, func(bo *tikv.Backoffer, loc *tikv.KeyLocation, keysInRegion [][]byte, primary []byte, startTs uint64) (int, bool, error) {
+		minCommitTs, maxCommitTs, err := c.commitTsBounds(ctx, startTs)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		} else if minCommitTs == 0 {
+			return 0, false, nil
+		}
+		req := tikvrpc.NewRequest(tikvrpc.CmdPrewrite, &kvrpcpb.PrewriteRequest{
+			Mutations:    putMutations(keysInRegion),
+			PrimaryLock:  primary,
+			StartVersion: startTs,
+			LockTtl:      30000,
+			TryOnePc:     true,
+			MinCommitTs:  minCommitTs,
+			MaxCommitTs:  maxCommitTs,
+		})
+		lockedKeys, resp, retry, err := c.sendPrewrite(bo, loc, keysInRegion, req)
+		if err == nil && !retry && lockedKeys != 0 {
+			c.oracle.record(startTs, oracleEntry{
+				generator:       g.Name(),
+				primary:         primary,
+				expectNoPersist: resp.GetOnePcCommitTs() != 0,
+				commitTs:        resp.GetOnePcCommitTs(),
+			})
+		}
+		return lockedKeys, retry, err
+	})
+}
+
+// acquirePessimisticLocks issues an AcquirePessimisticLock RPC for keys in loc's region and
+// returns the forUpdateTs to use for the subsequent pessimistic Prewrite, or (_, true, nil)
+// on a region error so the caller re-locates the region and retries.
+func (c *resolveLockClient) acquirePessimisticLocks(ctx context.Context, bo *tikv.Backoffer, loc *tikv.KeyLocation, keys [][]byte, primary []byte, startTs uint64) (uint64, bool, error) {
+	forUpdateTs, err := c.getTs(ctx)
+	if err != nil {
+		return 0, false, errors.Trace(err)
+	} else if forUpdateTs == 0 {
+		return 0, false, nil
+	}
+
+	mutations := make([]*kvrpcpb.Mutation, 0, len(keys))
+	for _, key := range keys {
+		mutations = append(mutations, &kvrpcpb.Mutation{
+			Op:  kvrpcpb.Op_PessimisticLock,
+			Key: key,
+		})
+	}
+
+	req := tikvrpc.NewRequest(
+		tikvrpc.CmdPessimisticLock,
+		&kvrpcpb.PessimisticLockRequest{
+			Mutations:    mutations,
+			PrimaryLock:  primary,
+			StartVersion: startTs,
+			ForUpdateTs:  forUpdateTs,
+			LockTtl:      30000,
+		},
+	)
+
+	resp, err := c.kv.SendReq(bo, req, loc.Region, time.Second*60)
+	if err != nil {
+		return 0, false, errors.Annotatef(err, "acquire pessimistic lock failed. region: %+v", loc.Region)
+	}
+	regionErr, err := resp.GetRegionError()
+	if err != nil {
+		return 0, false, errors.Trace(err)
+	}
+	if regionErr != nil {
+		if err := bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String())); err != nil {
+			return 0, false, errors.Trace(err)
+		}
+		return 0, true, nil
+	}
+	if resp.Resp == nil {
+		return 0, false, errors.Errorf("response body missing")
+	}
+	pessimisticLockResp := resp.Resp.(*kvrpcpb.PessimisticLockResponse)
+	if keyErr := pessimisticLockResp.GetErrors(); len(keyErr) != 0 {
+		return 0, false, errors.Errorf("failed to acquire pessimistic lock: %v", keyErr)
+	}
+	return forUpdateTs, false, nil
+}
+
+// lockOracle records what each generator wrote for a given start-ts, so CheckData can
+// classify a lock it finds after GC as an expected leftover or as a genuine bug.
+type lockOracle struct {
+	mu      sync.Mutex
+	entries map[uint64]oracleEntry
+}
+
+// oracleEntry is what a LockGenerator recorded about the transaction it wrote.
+type oracleEntry struct {
+	generator string
+	primary   []byte
+	// expectNoPersist is true for generators (1PC) whose successful prewrite should never
+	// leave a durable lock behind; finding one is always a bug, regardless of safePoint.
+	expectNoPersist bool
+	// commitTs is the version a 1PC prewrite actually committed at (PrewriteResponse.OnePcCommitTs);
+	// zero for every other generator. verifyLogBackupBarrier uses it to confirm GC didn't
+	// remove a record the log-backup checkpoint hasn't covered yet.
+	commitTs uint64
+}
+
+func newLockOracle() *lockOracle {
+	return &lockOracle{entries: make(map[uint64]oracleEntry)}
+}
+
+func (o *lockOracle) record(startTs uint64, entry oracleEntry) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries[startTs] = entry
+}
+
+func (o *lockOracle) lookup(startTs uint64) (oracleEntry, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entry, ok := o.entries[startTs]
+	return entry, ok
+}
+
+// committedSince returns every recorded entry whose commitTs falls in (checkpoint, upperBound].
+// Entries without a commitTs (everything but a genuinely-1PC-committed prewrite) never match,
+// since checkpoint is always > 0 when this is called.
+func (o *lockOracle) committedSince(checkpoint, upperBound uint64) []oracleEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var entries []oracleEntry
+	for _, entry := range o.entries {
+		if entry.commitTs > checkpoint && entry.commitTs <= upperBound {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// prune drops entries below safePoint once a round has finished with them.
+func (o *lockOracle) prune(safePoint uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for startTs := range o.entries {
+		if startTs < safePoint {
+			delete(o.entries, startTs)
+		}
+	}
+}
+
+// weightedGenerators picks a LockGenerator at random, weighted by Config.Generators.
+type weightedGenerators struct {
+	names   []string
+	weights []int
+	total   int
+}
+
+func newWeightedGenerators(cfg map[string]int) (*weightedGenerators, error) {
+	w := &weightedGenerators{}
+	for name, weight := range cfg {
+		if weight <= 0 {
+			continue
+		}
+		if _, ok := lockGenerators[name]; !ok {
+			return nil, errors.Errorf("unknown lock generator %q", name)
+		}
+		w.names = append(w.names, name)
+		w.weights = append(w.weights, weight)
+		w.total += weight
+	}
+	if w.total == 0 {
+		return nil, errors.New("no lock generators configured")
+	}
+	return w, nil
+}
+
+func (w *weightedGenerators) pick() LockGenerator {
+	r := rand.Intn(w.total)
+	for i, weight := range w.weights {
+		if r < weight {
+			return lockGenerators[w.names[i]]
+		}
+		r -= weight
+	}
+	return lockGenerators[w.names[len(w.names)-1]]
+}
+
+// verifyLogBackupBarrier asserts that GC didn't physically remove a committed record the
+// log-backup checkpoint hasn't advanced past yet, catching a GC that disregards the barrier
+// and runs at rawSafePoint instead of the checkpoint-capped c.safePoint. The oracle's only
+// genuinely committed records come from onePCGenerator's successful 1PC prewrites, which
+// commit atomically server-side at OnePcCommitTs without a separate Commit RPC.
+func (c *resolveLockClient) verifyLogBackupBarrier(ctx context.Context, loopNum int, checkpoint, rawSafePoint uint64) error {
+	if checkpoint == 0 || checkpoint >= rawSafePoint {
+		// Nothing falls in the barrier window (checkpoint, rawSafePoint] this round.
+		return nil
+	}
+	for _, entry := range c.oracle.committedSince(checkpoint, rawSafePoint) {
+		found, err := c.getAt(ctx, entry.primary, entry.commitTs)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !found {
+			log.Errorf("[round-%d] log backup barrier violated: committed record at commitTs(%v) in (checkpoint(%v), rawSafePoint(%v)] missing after GC at safePoint(%v)",
+				loopNum, entry.commitTs, checkpoint, rawSafePoint, c.safePoint)
+			return errors.New("log backup barrier violated: GC removed a record log backup hasn't advanced past")
+		}
+	}
+	return nil
+}
 
-		// Send the requests
+// getAt issues a point Get for key at the given version and reports whether a value was
+// found, retrying on region errors.
+func (c *resolveLockClient) getAt(ctx context.Context, key []byte, version uint64) (bool, error) {
+	bo := tikv.NewBackoffer(ctx, 60000)
+	for {
+		loc, err := c.kv.GetRegionCache().LocateKey(bo, key)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		req := tikvrpc.NewRequest(tikvrpc.CmdGet, &kvrpcpb.GetRequest{Key: key, Version: version})
 		resp, err := c.kv.SendReq(bo, req, loc.Region, time.Second*60)
 		if err != nil {
-			return 0, errors.Annotatef(err, "send request failed. region: %+v [%+q, %+q), keys: %+q", loc.Region, loc.StartKey, loc.EndKey, keys[0:lockedKeys])
+			return false, errors.Trace(err)
 		}
 		regionErr, err := resp.GetRegionError()
 		if err != nil {
-			return 0, errors.Trace(err)
+			return false, errors.Trace(err)
+		}
+		if regionErr != nil {
+			if err := bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String())); err != nil {
+				return false, errors.Trace(err)
+			}
+			continue
+		}
+		if resp.Resp == nil {
+			return false, errors.Errorf("missing response body")
+		}
+		getResp := resp.Resp.(*kvrpcpb.GetResponse)
+		if keyErr := getResp.GetError(); keyErr != nil {
+			return false, errors.Errorf("unexpected get error: %s", keyErr)
+		}
+		return len(getResp.GetValue()) != 0, nil
+	}
+}
+
+// verifyGreenGC gives green GC real coverage instead of trusting the server-reported
+// physicalUsed boolean: after GC it independently runs a physical scan and a logical scan
+// (resolving nothing in between), and fails the round if the two scans disagree on which
+// transactions (by start-ts) still hold locks below the safe point.
+func (c *resolveLockClient) verifyGreenGC(ctx context.Context, loopNum int) error {
+	physicalLocks, err := c.physicalScanLocks(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	logicalLocks, err := c.logicalScanLocks(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	physicalTxns := make(map[uint64]struct{}, len(physicalLocks))
+	for _, lock := range physicalLocks {
+		if lock.TxnID < c.safePoint {
+			physicalTxns[lock.TxnID] = struct{}{}
+		}
+	}
+	logicalTxns := make(map[uint64]struct{}, len(logicalLocks))
+	for _, lock := range logicalLocks {
+		if lock.TxnID < c.safePoint {
+			logicalTxns[lock.TxnID] = struct{}{}
+		}
+	}
+
+	var onlyPhysical, onlyLogical []uint64
+	for ts := range physicalTxns {
+		if _, ok := logicalTxns[ts]; !ok {
+			onlyPhysical = append(onlyPhysical, ts)
+		}
+	}
+	for ts := range logicalTxns {
+		if _, ok := physicalTxns[ts]; !ok {
+			onlyLogical = append(onlyLogical, ts)
+		}
+	}
+	if len(onlyPhysical) != 0 || len(onlyLogical) != 0 {
+		log.Errorf("[round-%d] green GC physical/logical scan mismatch at safePoint(%v): onlyPhysical=%v onlyLogical=%v",
+			loopNum, c.safePoint, onlyPhysical, onlyLogical)
+		return errors.New("green GC physical/logical lock scan mismatch")
+	}
+	return nil
+}
+
+// physicalScanLocks replays the physical-scan half of green GC by issuing
+// CmdPhysicalScanLock directly, walking the whole keyspace with an incrementing cursor.
+func (c *resolveLockClient) physicalScanLocks(ctx context.Context) ([]*tikv.Lock, error) {
+	const limit = 128
+
+	var locks []*tikv.Lock
+	key := make([]byte, 0)
+	for {
+		bo := tikv.NewBackoffer(ctx, 60000)
+		loc, err := c.kv.GetRegionCache().LocateKey(bo, key)
+		if err != nil {
+			return locks, errors.Trace(err)
+		}
+
+		req := tikvrpc.NewRequest(tikvrpc.CmdPhysicalScanLock, &kvrpcpb.PhysicalScanLockRequest{
+			StartKey: key,
+			Limit:    limit,
+			MaxTs:    c.safePoint,
+		})
+		resp, err := c.kv.SendReq(bo, req, loc.Region, 60*time.Second)
+		if err != nil {
+			return locks, errors.Trace(err)
+		}
+		regionErr, err := resp.GetRegionError()
+		if err != nil {
+			return locks, errors.Trace(err)
 		}
 		if regionErr != nil {
 			err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
-				return 0, errors.Trace(err)
+				return locks, errors.Trace(err)
 			}
 			continue
 		}
 		if resp.Resp == nil {
-			return 0, errors.Errorf("response body missing")
+			return locks, errors.New("missing response body")
 		}
-		prewriteResp := resp.Resp.(*kvrpcpb.PrewriteResponse)
-		keyErrors := prewriteResp.GetErrors()
-		if len(keyErrors) != 0 {
-			log.Warnf("failed to write locks: %v", keyErrors)
-			lockedKeys = 0
+		physicalScanResp := resp.Resp.(*kvrpcpb.PhysicalScanLockResponse)
+		if physicalScanResp.GetError() != "" {
+			return locks, errors.Errorf("unexpected physical scan lock error: %s", physicalScanResp.GetError())
 		}
 
-		return lockedKeys, nil
+		locksInfo := physicalScanResp.GetLocks()
+		for _, info := range locksInfo {
+			locks = append(locks, tikv.NewLock(info))
+		}
+		if len(locksInfo) < limit {
+			key = loc.EndKey
+		} else {
+			key = locksInfo[len(locksInfo)-1].GetKey()
+		}
+		if len(key) == 0 {
+			break
+		}
 	}
+	return locks, nil
+}
+
+// logicalScanLocks is a read-only counterpart to CheckData's scan: it walks every region's
+// locks via CmdScanLock without resolving anything, so it can be diffed against a physical
+// scan take on the same safe point.
+func (c *resolveLockClient) logicalScanLocks(ctx context.Context) ([]*tikv.Lock, error) {
+	const scanLockLimit = 100
+
+	req := tikvrpc.NewRequest(tikvrpc.CmdScanLock, &kvrpcpb.ScanLockRequest{
+		Limit:      scanLockLimit,
+		MaxVersion: c.safePoint,
+	})
+
+	var locks []*tikv.Lock
+	key := make([]byte, 0)
+	for {
+		bo := tikv.NewBackoffer(ctx, 60000)
+
+		req.ScanLock().StartKey = key
+		loc, err := c.kv.GetRegionCache().LocateKey(bo, key)
+		if err != nil {
+			return locks, errors.Trace(err)
+		}
+		resp, err := c.kv.SendReq(bo, req, loc.Region, 60*time.Second)
+		if err != nil {
+			return locks, errors.Trace(err)
+		}
+		regionErr, err := resp.GetRegionError()
+		if err != nil {
+			return locks, errors.Trace(err)
+		}
+		if regionErr != nil {
+			err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String()))
+			if err != nil {
+				return locks, errors.Trace(err)
+			}
+			continue
+		}
+		if resp.Resp == nil {
+			return locks, errors.New("missing response body")
+		}
+		scanLockResp := resp.Resp.(*kvrpcpb.ScanLockResponse)
+		if scanLockResp.GetError() != nil {
+			return locks, errors.Errorf("unexpected scanlock error: %s", scanLockResp)
+		}
+
+		locksInfo := scanLockResp.GetLocks()
+		for _, info := range locksInfo {
+			locks = append(locks, tikv.NewLock(info))
+		}
+		if len(locksInfo) < scanLockLimit {
+			key = loc.EndKey
+		} else {
+			key = locksInfo[len(locksInfo)-1].GetKey()
+		}
+		if len(key) == 0 {
+			break
+		}
+	}
+	return locks, nil
 }
 
 func (c *resolveLockClient) CheckData(ctx context.Context) ([]*tikv.Lock, error) {
@@ -519,6 +1319,25 @@ func (c *resolveLockClient) CheckData(ctx context.Context) ([]*tikv.Lock, error)
 		safeLocks := make([]*tikv.Lock, 0, len(locksInfo))
 		for _, info := range locksInfo {
 			lock := tikv.NewLock(info)
+			if entry, ok := c.oracle.lookup(lock.TxnID); ok && entry.expectNoPersist {
+				// A 1PC prewrite is supposed to commit atomically and never leave a lock
+				// behind, regardless of how it compares to safePoint/safeLockTs.
+				log.Errorf("found unexpected lock for %s txn(%v) that should never persist", entry.generator, lock.TxnID)
+				unsafeLocks = append(unsafeLocks, lock)
+				continue
+			}
+			if primary, ok := c.pessimisticPrimaries.Load(lock.TxnID); ok && bytes.Equal(lock.Key, primary.([]byte)) && lock.TxnID >= c.safeLockTs && lock.TxnID < c.safePoint {
+				// GC must have rolled back or resolved every main-phase primary pessimistic
+				// lock (startTs in [safeLockTs, safePoint)). Locks below safeLockTs come from
+				// asyncGenerateLocksDuringGC and are intentionally allowed to survive GC, so
+				// they're excluded here and handled by the safeLocks branch below instead.
+				// Note the lock's type has already turned from Op_PessimisticLock into Op_Put
+				// by the time pessimisticGenerator's prewrite succeeds, so pessimisticPrimaries
+				// is the only way to identify it.
+				log.Errorf("found leftover primary pessimistic lock for txn(%v) after GC at safePoint(%v)", lock.TxnID, c.safePoint)
+				unsafeLocks = append(unsafeLocks, lock)
+				continue
+			}
 			if lock.TxnID < c.safeLockTs {
 				safeLocks = append(safeLocks, lock)
 			} else {
@@ -554,11 +1373,24 @@ func (c *resolveLockClient) CheckData(ctx context.Context) ([]*tikv.Lock, error)
 
 func (c *resolveLockClient) reset(ctx context.Context) {
 	c.handleID = 0
+	c.prunePessimisticPrimaries()
+	c.oracle.prune(c.safePoint)
 	c.safePoint = 0
 	c.safeLockTs = 0
 	c.mockLockTs = 0
 }
 
+// prunePessimisticPrimaries drops primaries of transactions that have already been through
+// a GC round, so the tracked set doesn't grow unboundedly across rounds.
+func (c *resolveLockClient) prunePessimisticPrimaries() {
+	c.pessimisticPrimaries.Range(func(key, _ interface{}) bool {
+		if startTs := key.(uint64); startTs < c.safePoint {
+			c.pessimisticPrimaries.Delete(key)
+		}
+		return true
+	})
+}
+
 func (c *resolveLockClient) getTs(ctx context.Context) (uint64, error) {
 	bo := tikv.NewBackoffer(ctx, 60000)
 	for {